@@ -0,0 +1,112 @@
+package rest
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// BindFields sets named scalar fields on val from vals, the same
+// field-path binding grpc-gateway applies to path variables: a key matches
+// a field by its protobuf wire name (the generated struct's
+// `protobuf:"...,name=x"` tag) or, failing that, by case-insensitive Go
+// field name. Non-struct values and unmatched keys are ignored.
+//
+// BindFields is exported so other packages that bind HTTP values onto
+// messages by the same rules, such as transcode, can share this
+// implementation instead of duplicating it.
+func BindFields(val interface{}, vals map[string]string) error {
+	v := reflect.ValueOf(val)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	elem := v.Elem()
+	t := elem.Type()
+	for key, s := range vals {
+		if s == "" {
+			continue
+		}
+		fv := fieldByName(elem, t, key)
+		if !fv.IsValid() {
+			continue
+		}
+		if err := setScalar(fv, s); err != nil {
+			return fmt.Errorf("field %q: %s", key, err.Error())
+		}
+	}
+	return nil
+}
+
+// BindQuery is BindFields for a url.Values, taking the first value of each
+// query parameter.
+func BindQuery(val interface{}, query url.Values) error {
+	vals := make(map[string]string, len(query))
+	for k, v := range query {
+		if len(v) > 0 {
+			vals[k] = v[0]
+		}
+	}
+	return BindFields(val, vals)
+}
+
+func fieldByName(elem reflect.Value, t reflect.Type, key string) reflect.Value {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if wireName(f) == key || strings.EqualFold(f.Name, key) {
+			return elem.Field(i)
+		}
+	}
+	return reflect.Value{}
+}
+
+func wireName(f reflect.StructField) string {
+	tag := f.Tag.Get("protobuf")
+	for _, part := range strings.Split(tag, ",") {
+		if strings.HasPrefix(part, "name=") {
+			return strings.TrimPrefix(part, "name=")
+		}
+	}
+	return ""
+}
+
+func setScalar(fv reflect.Value, s string) error {
+	if fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		fv = fv.Elem()
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int32, reflect.Int64, reflect.Int:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint32, reflect.Uint64, reflect.Uint:
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported field type %s", fv.Kind())
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package rest
+
+import (
+	"net/url"
+	"testing"
+)
+
+type bindTarget struct {
+	Email *string `protobuf:"bytes,1,opt,name=email,json=email"`
+	Age   *int32  `protobuf:"varint,2,opt,name=age,json=age"`
+}
+
+func TestBindFields(t *testing.T) {
+	v := &bindTarget{}
+	if err := BindFields(v, map[string]string{"email": "alice@foo.com"}); err != nil {
+		t.Fatalf("BindFields returned error: %s", err.Error())
+	}
+	if v.Email == nil || *v.Email != "alice@foo.com" {
+		t.Fatalf("Email not bound: %v", v.Email)
+	}
+}
+
+func TestBindQuery(t *testing.T) {
+	v := &bindTarget{}
+	query := url.Values{"age": []string{"30"}}
+	if err := BindQuery(v, query); err != nil {
+		t.Fatalf("BindQuery returned error: %s", err.Error())
+	}
+	if v.Age == nil || *v.Age != 30 {
+		t.Fatalf("Age not bound: %v", v.Age)
+	}
+}
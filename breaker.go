@@ -0,0 +1,104 @@
+package rest
+
+import (
+	"sync"
+	"time"
+)
+
+// CircuitBreakerState is the state of a CircuitBreaker.
+type CircuitBreakerState int
+
+const (
+	Closed CircuitBreakerState = iota
+	Open
+	HalfOpen
+)
+
+// CircuitBreaker trips Open once the failure ratio over a sliding window of
+// the last WindowSize requests reaches FailureThreshold, short-circuiting
+// Client.request until OpenDuration has elapsed. It then allows a single
+// half-open probe request: success closes the breaker, failure reopens it.
+type CircuitBreaker struct {
+	FailureThreshold float64
+	WindowSize       int
+	OpenDuration     time.Duration
+
+	mu       sync.Mutex
+	results  []bool
+	state    CircuitBreakerState
+	openedAt time.Time
+	probing  bool
+}
+
+// NewCircuitBreaker returns a CircuitBreaker that opens once at least
+// failureThreshold of the last windowSize requests failed, reopening for
+// probing after openDuration.
+func NewCircuitBreaker(failureThreshold float64, windowSize int, openDuration time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		FailureThreshold: failureThreshold,
+		WindowSize:       windowSize,
+		OpenDuration:     openDuration,
+	}
+}
+
+// State reports the breaker's current state.
+func (cb *CircuitBreaker) State() CircuitBreakerState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
+
+func (cb *CircuitBreaker) allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	switch cb.state {
+	case Open:
+		if time.Since(cb.openedAt) < cb.OpenDuration {
+			return false
+		}
+		cb.state = HalfOpen
+		cb.probing = true
+		return true
+	case HalfOpen:
+		if cb.probing {
+			return false
+		}
+		cb.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+func (cb *CircuitBreaker) record(success bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if cb.state == HalfOpen {
+		cb.probing = false
+		if success {
+			cb.state = Closed
+			cb.results = nil
+		} else {
+			cb.state = Open
+			cb.openedAt = time.Now()
+		}
+		return
+	}
+	cb.results = append(cb.results, success)
+	if len(cb.results) > cb.WindowSize {
+		cb.results = cb.results[len(cb.results)-cb.WindowSize:]
+	}
+	if len(cb.results) < cb.WindowSize {
+		return
+	}
+	failures := 0
+	for _, ok := range cb.results {
+		if !ok {
+			failures++
+		}
+	}
+	if float64(failures)/float64(len(cb.results)) >= cb.FailureThreshold {
+		cb.state = Open
+		cb.openedAt = time.Now()
+	}
+}
@@ -0,0 +1,32 @@
+package rest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerTripsAndRecovers(t *testing.T) {
+	cb := NewCircuitBreaker(0.5, 4, 10*time.Millisecond)
+
+	for i := 0; i < 4; i++ {
+		if !cb.allow() {
+			t.Fatalf("expected closed breaker to allow request %d", i)
+		}
+		cb.record(false)
+	}
+	if cb.State() != Open {
+		t.Fatalf("expected breaker to trip open after failures, got %v", cb.State())
+	}
+	if cb.allow() {
+		t.Fatal("expected open breaker to block requests")
+	}
+
+	time.Sleep(15 * time.Millisecond)
+	if !cb.allow() {
+		t.Fatal("expected breaker to allow a half-open probe after OpenDuration")
+	}
+	cb.record(true)
+	if cb.State() != Closed {
+		t.Fatalf("expected a successful probe to close the breaker, got %v", cb.State())
+	}
+}
@@ -2,25 +2,20 @@ package rest
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
-	"github.com/golang/protobuf/proto"
 	"github.com/jetrtc/log"
 )
 
-const (
-	contentTypeHeader = "Content-Type"
-	jsonContentType   = "application/json"
-	protoContentType  = "application/protobuf"
-)
-
 type Auth interface {
 	Authorize(req *http.Request) error
 	Validate(res *Response) (bool, error)
@@ -74,23 +69,24 @@ type Response struct {
 	*http.Response
 	Body     []byte
 	protobuf bool
+	codecs   *codecRegistry
 }
 
 func (r *Response) Decode(val interface{}) error {
-	var err error
-	protobuf := false
-	switch val := val.(type) {
-	case proto.Message:
-		if r.protobuf {
-			protobuf = true
-			err = proto.Unmarshal(r.Body, val)
-		} else {
-			err = json.Unmarshal(r.Body, val)
+	ct := r.Response.Header.Get(ContentType)
+	if strings.HasPrefix(ct, ProblemContentType) {
+		p := &Problem{}
+		if err := json.Unmarshal(r.Body, p); err != nil {
+			return fmt.Errorf("%s", r.Body)
 		}
-	default:
-		err = json.Unmarshal(r.Body, val)
+		return p
 	}
-	if err != nil && !protobuf && r.Response.Header.Get(contentTypeHeader) != jsonContentType {
+	codec := r.codecs.byContentType(ct)
+	if codec == nil {
+		codec = &JSONCodec{}
+	}
+	err := codec.Unmarshal(r.Body, val)
+	if err != nil && !r.protobuf && ct != JsonContentType {
 		err = fmt.Errorf("%s", r.Body)
 	}
 	return err
@@ -102,12 +98,17 @@ type Client struct {
 	URL      string
 	auth     Auth
 	protobuf bool
+	codecs   *codecRegistry
+	tracer   Tracer
+	retry    *RetryPolicy
+	breaker  *CircuitBreaker
 }
 
 func NewClient(logger log.Logger, timeout time.Duration) *Client {
 	return &Client{
 		Loggable: log.NewLoggable(logger),
 		client:   &http.Client{Timeout: 5 * time.Second},
+		codecs:   newCodecRegistry(&ProtoCodec{}, &JSONCodec{}),
 	}
 }
 
@@ -121,6 +122,35 @@ func (c *Client) Protobuf() *Client {
 	return c
 }
 
+// RegisterCodec adds codec to the client's codec registry, ahead of the
+// built-in JSON and protobuf codecs, so it is tried first for matching
+// content types.
+func (c *Client) RegisterCodec(codec Codec) *Client {
+	c.codecs.register(codec)
+	return c
+}
+
+// Retry enables automatic retries of transient failures per policy.
+func (c *Client) Retry(policy RetryPolicy) *Client {
+	c.retry = &policy
+	return c
+}
+
+// Breaker short-circuits requests with cb once it has tripped open.
+func (c *Client) Breaker(cb *CircuitBreaker) *Client {
+	c.breaker = cb
+	return c
+}
+
+// accepts returns the client's preferred content types, most preferred
+// first, used to pick the codec that marshals a request body.
+func (c *Client) accepts() []string {
+	if c.protobuf {
+		return []string{ProtobufContentTypes[0], JsonContentType}
+	}
+	return []string{JsonContentType}
+}
+
 func (c *Client) New(u string) *Request {
 	return &Request{client: c, url: NewURL(u), header: make(http.Header)}
 }
@@ -170,43 +200,73 @@ func (c *Client) Request(method, url string, r interface{}) (*Response, error) {
 }
 
 func (c *Client) request(method string, header http.Header, url string, r interface{}) (*Response, error) {
-	var body []byte
-	var err error
-	protobuf := false
-	isJson := false
+	body, codec, err := c.marshalBody(r)
+	if err != nil {
+		return nil, err
+	}
+	if c.breaker != nil && !c.breaker.allow() {
+		err := fmt.Errorf("rest: circuit breaker open")
+		c.Errorf("Blocked request: %s", err.Error())
+		return nil, err
+	}
+
+	attempts := 1
+	if c.retry != nil && c.retry.MaxAttempts > 1 {
+		attempts = c.retry.MaxAttempts
+	}
+	var res *Response
+	for attempt := 1; attempt <= attempts; attempt++ {
+		res, err = c.do(method, header, url, body, codec)
+		if c.breaker != nil {
+			c.breaker.record(!c.isFailure(res, err))
+		}
+		retry := attempt < attempts && c.shouldRetry(res, err)
+		if !retry {
+			break
+		}
+		c.Debugf("Retrying %s %s (attempt %d/%d): %v", method, url, attempt+1, attempts, err)
+		time.Sleep(c.retryDelay(attempt, res))
+	}
+	return res, err
+}
+
+// marshalBody turns r into the bytes to send, picking a Codec for values
+// that aren't already a raw io.Reader or []byte.
+func (c *Client) marshalBody(r interface{}) ([]byte, Codec, error) {
 	switch v := r.(type) {
 	case io.Reader:
-		body, err = ioutil.ReadAll(v)
+		body, err := ioutil.ReadAll(v)
 		if err != nil {
 			c.Errorf("Failed to read request body: %s", err.Error())
-			return nil, err
+			return nil, nil, err
 		}
+		return body, nil, nil
 	default:
 		t := reflect.ValueOf(r)
 		if t.Kind() == reflect.Slice && t.Type() == reflect.TypeOf([]byte(nil)) {
-			body = r.([]byte)
-		} else {
-			if r != nil {
-				switch r := r.(type) {
-				case proto.Message:
-					if c.protobuf {
-						protobuf = true
-						body, err = proto.Marshal(r)
-					} else {
-						isJson = true
-						body, err = json.Marshal(r)
-					}
-				default:
-					isJson = true
-					body, err = json.Marshal(r)
-				}
-				if err != nil {
-					c.Errorf("Failed to marshal: %s", err.Error())
-					return nil, err
-				}
-			}
+			return r.([]byte), nil, nil
+		}
+		if r == nil {
+			return nil, nil, nil
+		}
+		codec := c.codecs.negotiate(r, c.accepts())
+		if codec == nil {
+			err := fmt.Errorf("rest: no codec supports %T", r)
+			c.Errorf("Failed to marshal: %s", err.Error())
+			return nil, nil, err
+		}
+		body, err := codec.Marshal(r)
+		if err != nil {
+			c.Errorf("Failed to marshal: %s", err.Error())
+			return nil, nil, err
 		}
+		return body, codec, nil
 	}
+}
+
+// do performs a single attempt: body is replayed fresh into a new
+// http.Request each time it's called, so callers can retry it as-is.
+func (c *Client) do(method string, header http.Header, url string, body []byte, codec Codec) (*Response, error) {
 	req, err := http.NewRequest(method, c.URL+url, bytes.NewBuffer(body))
 	if err != nil {
 		c.Errorf("Failed to create request: %s", err.Error())
@@ -229,20 +289,26 @@ func (c *Client) request(method string, header http.Header, url string, r interf
 		c.auth = auth
 	}
 	if body != nil && len(body) > 0 {
-		if protobuf {
-			req.Header.Set(contentTypeHeader, protoContentType)
-		} else if isJson {
-			req.Header.Set(contentTypeHeader, jsonContentType)
-		}
-	} else {
-		if c.protobuf {
-			req.Header.Set("Accept", protoContentType)
-		} else if isJson {
-			req.Header.Set("Accept", jsonContentType)
+		if codec != nil {
+			req.Header.Set(ContentType, codec.ContentType())
 		}
+	} else if c.protobuf {
+		req.Header.Set("Accept", ProtobufContentTypes[0])
+	}
+	var finish func(res *http.Response, err error)
+	if c.tracer != nil {
+		finish = c.tracer.Propagate(req)
+	}
+	if c.retry != nil && c.retry.Timeout > 0 {
+		ctx, cancel := context.WithTimeout(req.Context(), c.retry.Timeout)
+		defer cancel()
+		req = req.WithContext(ctx)
 	}
 	c.dumpRequest(req, body)
 	res, err := c.client.Do(req)
+	if finish != nil {
+		finish(res, err)
+	}
 	if err != nil {
 		c.Errorf("Failed to make request: %s", err.Error())
 		return nil, err
@@ -260,9 +326,59 @@ func (c *Client) request(method string, header http.Header, url string, r interf
 		Response: res,
 		Body:     data,
 		protobuf: c.protobuf,
+		codecs:   c.codecs,
 	}, nil
 }
 
+func (c *Client) shouldRetry(res *Response, err error) bool {
+	if c.retry == nil {
+		return false
+	}
+	if err != nil {
+		return c.retry.retryableError(err)
+	}
+	return c.retry.retryableStatus(res.StatusCode)
+}
+
+// isFailure reports whether an attempt counts as a failure for
+// Client.breaker, independent of whether a RetryPolicy is configured or
+// considers the response retryable: a transport error or any 5xx response
+// trips the breaker's failure count.
+func (c *Client) isFailure(res *Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return res.StatusCode >= 500
+}
+
+func (c *Client) retryDelay(attempt int, res *Response) time.Duration {
+	if res != nil {
+		if d, ok := retryAfter(res.Response); ok {
+			return d
+		}
+	}
+	return c.retry.backoff(attempt)
+}
+
+// retryAfter reads a 429 or 503 response's Retry-After header, which may be
+// either a number of seconds or an HTTP date.
+func retryAfter(res *http.Response) (time.Duration, bool) {
+	if res.StatusCode != http.StatusTooManyRequests && res.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+	v := res.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t), true
+	}
+	return 0, false
+}
+
 func (c *Client) dumpRequest(req *http.Request, data []byte) {
 	dump := &struct {
 		Method   string                 `json:"method"`
@@ -284,7 +400,7 @@ func (c *Client) dumpRequest(req *http.Request, data []byte) {
 			dump.Headers[k] = v
 		}
 	}
-	if data != nil && len(data) > 0 && strings.HasPrefix(req.Header.Get(contentTypeHeader), jsonContentType) {
+	if data != nil && len(data) > 0 && strings.HasPrefix(req.Header.Get(ContentType), JsonContentType) {
 		dump.Body = json.RawMessage(data)
 	}
 	bytes, err := json.Marshal(dump)
@@ -312,7 +428,7 @@ func (c *Client) dumpResponse(res *http.Response, data []byte) {
 			dump.Headers[k] = v
 		}
 	}
-	if data != nil && len(data) > 0 && strings.HasPrefix(res.Header.Get(contentTypeHeader), jsonContentType) {
+	if data != nil && len(data) > 0 && strings.HasPrefix(res.Header.Get(ContentType), JsonContentType) {
 		dump.Body = json.RawMessage(data)
 	}
 	bytes, err := json.Marshal(dump)
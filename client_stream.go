@@ -0,0 +1,124 @@
+package rest
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/jetrtc/log"
+)
+
+// Stream performs the request like Do but leaves the response body open for
+// incremental reads instead of buffering it, so the caller can decode
+// messages as they arrive via Response.Stream.
+func (r *Request) Stream(method string) (*Response, error) {
+	url := r.url.Encode()
+	return r.client.stream(method, r.header, url)
+}
+
+func (c *Client) stream(method string, header http.Header, url string) (*Response, error) {
+	req, err := http.NewRequest(method, c.URL+url, nil)
+	if err != nil {
+		c.Errorf("Failed to create request: %s", err.Error())
+		return nil, err
+	}
+	if header != nil {
+		for k, v := range header {
+			req.Header[k] = v
+		}
+	}
+	if c.auth != nil {
+		if err := c.auth.Authorize(req); err != nil {
+			c.Errorf("Failed to authorize: %s", err.Error())
+			return nil, err
+		}
+	}
+	if c.protobuf {
+		req.Header.Set("Accept", ProtobufContentTypes[0])
+	}
+	var finish func(res *http.Response, err error)
+	if c.tracer != nil {
+		finish = c.tracer.Propagate(req)
+	}
+	c.dumpRequest(req, nil)
+	res, err := c.client.Do(req)
+	if finish != nil {
+		finish(res, err)
+	}
+	if err != nil {
+		c.Errorf("Failed to make request: %s", err.Error())
+		return nil, err
+	}
+	return &Response{
+		Loggable: log.NewLoggable(c),
+		Response: res,
+		protobuf: c.protobuf,
+		codecs:   c.codecs,
+	}, nil
+}
+
+// StreamDecoder decodes messages pushed over a chunked response body, either
+// newline-delimited JSON or length-prefixed protobuf frames depending on the
+// response's content type.
+type StreamDecoder struct {
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+	isProto bool
+}
+
+// Stream returns a StreamDecoder reading the response body as it arrives.
+// The caller must Close it when done.
+func (r *Response) Stream() *StreamDecoder {
+	isProto := strings.HasPrefix(r.Response.Header.Get(ContentType), ProtobufContentTypes[0])
+	return &StreamDecoder{
+		body:    r.Response.Body,
+		scanner: bufio.NewScanner(r.Response.Body),
+		isProto: isProto,
+	}
+}
+
+// Next decodes the next message into v, returning io.EOF once the stream ends.
+func (d *StreamDecoder) Next(v interface{}) error {
+	if d.isProto {
+		return d.nextProto(v)
+	}
+	return d.nextJSON(v)
+}
+
+func (d *StreamDecoder) nextJSON(v interface{}) error {
+	if !d.scanner.Scan() {
+		if err := d.scanner.Err(); err != nil {
+			return err
+		}
+		return io.EOF
+	}
+	return json.Unmarshal(d.scanner.Bytes(), v)
+}
+
+func (d *StreamDecoder) nextProto(v interface{}) error {
+	pm, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("rest: %T is not a proto.Message", v)
+	}
+	var size [4]byte
+	if _, err := io.ReadFull(d.body, size[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return io.EOF
+		}
+		return err
+	}
+	data := make([]byte, binary.BigEndian.Uint32(size[:]))
+	if _, err := io.ReadFull(d.body, data); err != nil {
+		return err
+	}
+	return proto.Unmarshal(data, pm)
+}
+
+func (d *StreamDecoder) Close() error {
+	return d.body.Close()
+}
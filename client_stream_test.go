@@ -0,0 +1,88 @@
+package rest
+
+import (
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/jetrtc/log"
+)
+
+func TestClientStreamDecodesNDJSON(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(ContentType, NDJSONContentType)
+		w.Write([]byte(`{"n":1}` + "\n" + `{"n":2}` + "\n"))
+	}))
+	defer srv.Close()
+
+	logger := log.NewLogger(func(lv log.Level, payload interface{}) {})
+	c := NewClient(logger, time.Second)
+	c.URL = srv.URL
+	res, err := c.New("/").Stream("GET")
+	if err != nil {
+		t.Fatalf("Stream returned error: %s", err.Error())
+	}
+	dec := res.Stream()
+	defer dec.Close()
+
+	var got []int
+	for {
+		var v struct {
+			N int `json:"n"`
+		}
+		err := dec.Next(&v)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next returned error: %s", err.Error())
+		}
+		got = append(got, v.N)
+	}
+	if len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Fatalf("unexpected decoded values: %v", got)
+	}
+}
+
+func TestClientStreamDecodesProtoFrames(t *testing.T) {
+	data, err := proto.Marshal(&streamTestMessage{Name: proto.String("alice")})
+	if err != nil {
+		t.Fatalf("Marshal returned error: %s", err.Error())
+	}
+	var frame []byte
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(data)))
+	frame = append(frame, size[:]...)
+	frame = append(frame, data...)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(ContentType, ProtobufContentTypes[0])
+		w.Write(frame)
+	}))
+	defer srv.Close()
+
+	logger := log.NewLogger(func(lv log.Level, payload interface{}) {})
+	c := NewClient(logger, time.Second)
+	c.URL = srv.URL
+	res, err := c.New("/").Stream("GET")
+	if err != nil {
+		t.Fatalf("Stream returned error: %s", err.Error())
+	}
+	dec := res.Stream()
+	defer dec.Close()
+
+	msg := &streamTestMessage{}
+	if err := dec.Next(msg); err != nil {
+		t.Fatalf("Next returned error: %s", err.Error())
+	}
+	if msg.Name == nil || *msg.Name != "alice" {
+		t.Fatalf("unexpected decoded message: %v", msg)
+	}
+	if err := dec.Next(msg); err != io.EOF {
+		t.Fatalf("expected io.EOF at end of stream, got %v", err)
+	}
+}
@@ -0,0 +1,30 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jetrtc/log"
+)
+
+func TestClientBreakerTripsOnServerErrorsWithoutRetryPolicy(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	logger := log.NewLogger(func(lv log.Level, payload interface{}) {})
+	c := NewClient(logger, time.Second)
+	c.URL = srv.URL
+	c.Breaker(NewCircuitBreaker(0.5, 4, time.Minute))
+
+	for i := 0; i < 6; i++ {
+		c.Get("/")
+	}
+
+	if c.breaker.State() != Open {
+		t.Fatalf("expected breaker to trip open on repeated 500s with no RetryPolicy, got %v", c.breaker.State())
+	}
+}
@@ -0,0 +1,185 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"gopkg.in/yaml.v2"
+)
+
+// YamlContentType is the media type written and accepted by YAMLCodec.
+const YamlContentType = "application/yaml"
+
+// Codec marshals and unmarshals values for one wire content type. Server
+// and Client each keep a registry of codecs and pick one by matching it
+// against a request's Content-Type or Accept header, replacing the
+// hardcoded JSON/protobuf switches that used to live in Session and Client.
+type Codec interface {
+	// ContentType is the canonical Content-Type this codec writes.
+	ContentType() string
+	// Accepts reports whether a Content-Type or Accept header value (which
+	// may carry parameters, e.g. "application/json; charset=utf-8") is
+	// handled by this codec.
+	Accepts(contentType string) bool
+	// Supports reports whether this codec can marshal v, e.g. the protobuf
+	// codec only supports proto.Message values.
+	Supports(v interface{}) bool
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// codecRegistry is the shared implementation behind Server.RegisterCodec
+// and Client.RegisterCodec.
+type codecRegistry struct {
+	codecs []Codec
+}
+
+func newCodecRegistry(defaults ...Codec) *codecRegistry {
+	return &codecRegistry{codecs: defaults}
+}
+
+// register adds c ahead of the existing codecs, so a user-registered codec
+// takes priority over the library defaults for the same content type.
+func (r *codecRegistry) register(c Codec) {
+	r.codecs = append([]Codec{c}, r.codecs...)
+}
+
+// byContentType returns the first registered codec that accepts contentType.
+func (r *codecRegistry) byContentType(contentType string) Codec {
+	for _, c := range r.codecs {
+		if c.Accepts(contentType) {
+			return c
+		}
+	}
+	return nil
+}
+
+// negotiate picks a codec for v, preferring one that Accepts an entry of
+// accepts (in the given preference order), then falling back to the first
+// registered codec that supports v.
+func (r *codecRegistry) negotiate(v interface{}, accepts []string) Codec {
+	for _, a := range accepts {
+		if c := r.byContentType(a); c != nil && c.Supports(v) {
+			return c
+		}
+	}
+	for _, c := range r.codecs {
+		if c.Supports(v) {
+			return c
+		}
+	}
+	return nil
+}
+
+// JSONCodec marshals with encoding/json and supports any value.
+type JSONCodec struct {
+	Prefix, Indent string
+}
+
+func (c *JSONCodec) ContentType() string             { return JsonContentType }
+func (c *JSONCodec) Accepts(contentType string) bool { return strings.HasPrefix(contentType, JsonContentType) }
+func (c *JSONCodec) Supports(v interface{}) bool     { return true }
+
+func (c *JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.MarshalIndent(v, c.Prefix, c.Indent)
+}
+
+func (c *JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// ProtoCodec marshals proto.Message values as binary protobuf, accepting
+// both the "application/protobuf" and "application/x-protobuf" media types.
+type ProtoCodec struct{}
+
+func (c *ProtoCodec) ContentType() string { return ProtobufContentTypes[0] }
+
+func (c *ProtoCodec) Accepts(contentType string) bool {
+	return isTypeOf(contentType, ProtobufContentTypes)
+}
+
+func (c *ProtoCodec) Supports(v interface{}) bool {
+	_, ok := v.(proto.Message)
+	return ok
+}
+
+func (c *ProtoCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("rest: %T is not a proto.Message", v)
+	}
+	return proto.Marshal(m)
+}
+
+func (c *ProtoCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("rest: %T is not a proto.Message", v)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+// YAMLCodec marshals with gopkg.in/yaml.v2 and supports any value. It is
+// not registered by default; add it with Server.RegisterCodec or
+// Client.RegisterCodec.
+type YAMLCodec struct{}
+
+func (c *YAMLCodec) ContentType() string { return YamlContentType }
+
+func (c *YAMLCodec) Accepts(contentType string) bool {
+	return strings.HasPrefix(contentType, YamlContentType)
+}
+
+func (c *YAMLCodec) Supports(v interface{}) bool { return true }
+
+func (c *YAMLCodec) Marshal(v interface{}) ([]byte, error) {
+	return yaml.Marshal(v)
+}
+
+func (c *YAMLCodec) Unmarshal(data []byte, v interface{}) error {
+	return yaml.Unmarshal(data, v)
+}
+
+// JSONPBCodec marshals proto.Message values with jsonpb, which honors
+// well-known types and the original proto field names the way gogo-proto's
+// jsonpb does, instead of encoding/json's generated struct tags. It is not
+// registered by default; once added with Server.RegisterCodec or
+// Client.RegisterCodec it takes priority over JSONCodec for proto messages
+// sent as application/json. ProtoCodec stays registered and is still chosen
+// for protobuf content types.
+type JSONPBCodec struct {
+	Marshaler   jsonpb.Marshaler
+	Unmarshaler jsonpb.Unmarshaler
+}
+
+func (c *JSONPBCodec) ContentType() string             { return JsonContentType }
+func (c *JSONPBCodec) Accepts(contentType string) bool { return strings.HasPrefix(contentType, JsonContentType) }
+
+func (c *JSONPBCodec) Supports(v interface{}) bool {
+	_, ok := v.(proto.Message)
+	return ok
+}
+
+func (c *JSONPBCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("rest: %T is not a proto.Message", v)
+	}
+	var buf bytes.Buffer
+	if err := c.Marshaler.Marshal(&buf, m); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c *JSONPBCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("rest: %T is not a proto.Message", v)
+	}
+	return c.Unmarshaler.Unmarshal(bytes.NewReader(data), m)
+}
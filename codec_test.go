@@ -0,0 +1,31 @@
+package rest
+
+import "testing"
+
+func TestCodecRegistryNegotiate(t *testing.T) {
+	r := newCodecRegistry(&ProtoCodec{}, &JSONCodec{})
+
+	type plain struct{ Name string }
+	codec := r.negotiate(&plain{Name: "bob"}, []string{ProtobufContentTypes[0], JsonContentType})
+	if _, ok := codec.(*JSONCodec); !ok {
+		t.Fatalf("expected JSONCodec for non-proto value, got %T", codec)
+	}
+}
+
+func TestCodecRegistryByContentType(t *testing.T) {
+	r := newCodecRegistry(&ProtoCodec{}, &JSONCodec{})
+	if _, ok := r.byContentType("application/x-protobuf").(*ProtoCodec); !ok {
+		t.Fatalf("expected ProtoCodec for application/x-protobuf")
+	}
+	if r.byContentType("application/xml") != nil {
+		t.Fatalf("expected no codec for unregistered content type")
+	}
+}
+
+func TestRegisterCodecTakesPriority(t *testing.T) {
+	r := newCodecRegistry(&JSONCodec{})
+	r.register(&YAMLCodec{})
+	if _, ok := r.codecs[0].(*YAMLCodec); !ok {
+		t.Fatalf("expected registered codec to take priority")
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/jetrtc/rest"
+)
+
+// AccessLog returns a MiddlewareFunc that emits one structured JSON line
+// per request: method, path, status, bytes written, duration, and remote
+// IP.
+func AccessLog() rest.MiddlewareFunc {
+	return func(next rest.HandlerFunc) rest.HandlerFunc {
+		return func(s *rest.Session) {
+			sw := &statusWriter{ResponseWriter: s.ResponseWriter}
+			s.ResponseWriter = sw
+			start := time.Now()
+			next(s)
+
+			entry := struct {
+				Method     string  `json:"method"`
+				Path       string  `json:"path"`
+				Status     int     `json:"status"`
+				Bytes      int     `json:"bytes"`
+				DurationMs float64 `json:"duration_ms"`
+				RemoteIP   string  `json:"remote_ip,omitempty"`
+			}{
+				Method:     s.Request.Method,
+				Path:       s.Request.URL.Path,
+				Status:     sw.status,
+				Bytes:      sw.bytes,
+				DurationMs: float64(time.Since(start)) / float64(time.Millisecond),
+			}
+			if ip := s.RemoteAddr(); ip != nil {
+				entry.RemoteIP = ip.String()
+			}
+			if data, err := json.Marshal(entry); err == nil {
+				s.Infof("%s", data)
+			}
+		}
+	}
+}
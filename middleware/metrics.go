@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jetrtc/rest"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics returns a MiddlewareFunc that registers and maintains request
+// count, latency, and in-flight gauges with reg, labeled by the matched
+// gorilla/mux route's path template rather than the raw, var-filled path.
+// Requests with no matched route are labeled by their raw path.
+func Metrics(reg prometheus.Registerer) rest.MiddlewareFunc {
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total HTTP requests by route, method, and status.",
+	}, []string{"route", "method", "status"})
+	latency := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by route and method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method"})
+	inFlight := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "In-flight HTTP requests by route.",
+	}, []string{"route"})
+	reg.MustRegister(requests, latency, inFlight)
+
+	return func(next rest.HandlerFunc) rest.HandlerFunc {
+		return func(s *rest.Session) {
+			route := routeTemplate(s)
+			inFlight.WithLabelValues(route).Inc()
+			defer inFlight.WithLabelValues(route).Dec()
+
+			sw := &statusWriter{ResponseWriter: s.ResponseWriter}
+			s.ResponseWriter = sw
+			start := time.Now()
+			next(s)
+
+			latency.WithLabelValues(route, s.Request.Method).Observe(time.Since(start).Seconds())
+			requests.WithLabelValues(route, s.Request.Method, strconv.Itoa(sw.status)).Inc()
+		}
+	}
+}
+
+func routeTemplate(s *rest.Session) string {
+	route := mux.CurrentRoute(s.Request)
+	if route == nil {
+		return s.Request.URL.Path
+	}
+	if tmpl, err := route.GetPathTemplate(); err == nil {
+		return tmpl
+	}
+	return s.Request.URL.Path
+}
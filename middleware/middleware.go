@@ -0,0 +1,37 @@
+// Package middleware provides rest.MiddlewareFunc implementations that plug
+// into rest.Server.Use: access logging, Prometheus metrics, request IDs,
+// and distributed tracing.
+package middleware
+
+import "net/http"
+
+// statusWriter wraps an http.ResponseWriter to capture the status code and
+// byte count a handler wrote, for middleware that reports on both.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += n
+	return n, err
+}
+
+// Flush implements http.Flusher by delegating to the wrapped
+// ResponseWriter, so streaming handlers keep working when this writer is
+// installed by middleware.
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
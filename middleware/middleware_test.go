@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jetrtc/log"
+	"github.com/jetrtc/rest"
+)
+
+// TestStatusWriterSupportsFlusher guards against a regression where
+// AccessLog/Metrics wrapped the ResponseWriter in a statusWriter that
+// didn't implement http.Flusher, breaking Session.Stream for any handler
+// installed behind either middleware.
+func TestStatusWriterSupportsFlusher(t *testing.T) {
+	handler := AccessLog()(func(s *rest.Session) {
+		if _, err := s.Stream(rest.NDJSONContentType); err != nil {
+			t.Fatalf("Stream returned error behind AccessLog: %s", err.Error())
+		}
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	logger := log.NewSugar(log.NewLogger(func(lv log.Level, payload interface{}) {}))
+	s := &rest.Session{
+		Context:        log.NewContext(req.Context(), logger),
+		Data:           make(map[interface{}]interface{}),
+		Request:        req,
+		ResponseWriter: w,
+	}
+	handler(s)
+}
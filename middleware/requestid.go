@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/jetrtc/log"
+	"github.com/jetrtc/rest"
+)
+
+// RequestIDHeader is the header read and written by RequestID.
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// RequestID returns a MiddlewareFunc that reads X-Request-ID from the
+// request, generating one if absent, sets it on the response, and threads
+// it into s.Context via context.WithValue so it propagates with the
+// context (e.g. into outbound requests or goroutines started from the
+// handler), not just a side-channel lookup. Handlers and other middleware
+// read it back with RequestIDFrom.
+func RequestID() rest.MiddlewareFunc {
+	return func(next rest.HandlerFunc) rest.HandlerFunc {
+		return func(s *rest.Session) {
+			id := s.RequestHeader().Get(RequestIDHeader)
+			if id == "" {
+				id = newRequestID()
+			}
+			ctx := context.WithValue(s.Context, requestIDKey{}, id)
+			s.Context = log.NewContext(ctx, s.Context)
+			s.ResponseHeader().Set(RequestIDHeader, id)
+			next(s)
+		}
+	}
+}
+
+// RequestIDFrom returns the request ID RequestID set on s's context, or ""
+// if the middleware wasn't installed.
+func RequestIDFrom(s *rest.Session) string {
+	id, _ := s.Value(requestIDKey{}).(string)
+	return id
+}
+
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jetrtc/log"
+	"github.com/jetrtc/rest"
+)
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	var seenInContext string
+	handler := RequestID()(func(s *rest.Session) {
+		seen = RequestIDFrom(s)
+		seenInContext, _ = s.Value(requestIDKey{}).(string)
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	logger := log.NewSugar(log.NewLogger(func(lv log.Level, payload interface{}) {}))
+	s := &rest.Session{
+		Context:        log.NewContext(req.Context(), logger),
+		Data:           make(map[interface{}]interface{}),
+		Request:        req,
+		ResponseWriter: w,
+	}
+	handler(s)
+
+	if seen == "" {
+		t.Fatal("expected a generated request ID")
+	}
+	if seenInContext != seen {
+		t.Fatalf("expected request ID to be reachable via s.Context.Value, got %q, want %q", seenInContext, seen)
+	}
+	if got := w.Header().Get(RequestIDHeader); got != seen {
+		t.Fatalf("response header mismatch: got %q, want %q", got, seen)
+	}
+}
+
+func TestRequestIDPreservesExisting(t *testing.T) {
+	var seen string
+	handler := RequestID()(func(s *rest.Session) {
+		seen = RequestIDFrom(s)
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	w := httptest.NewRecorder()
+	logger := log.NewSugar(log.NewLogger(func(lv log.Level, payload interface{}) {}))
+	s := &rest.Session{
+		Context:        log.NewContext(req.Context(), logger),
+		Data:           make(map[interface{}]interface{}),
+		Request:        req,
+		ResponseWriter: w,
+	}
+	handler(s)
+
+	if seen != "abc-123" {
+		t.Fatalf("expected existing request ID to be preserved, got %q", seen)
+	}
+}
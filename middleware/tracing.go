@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/jetrtc/rest"
+)
+
+// Tracing returns a MiddlewareFunc that starts a span per request with
+// tracer, extracting any incoming W3C traceparent header so the span joins
+// the caller's trace.
+func Tracing(tracer trace.Tracer) rest.MiddlewareFunc {
+	propagator := propagation.TraceContext{}
+	return func(next rest.HandlerFunc) rest.HandlerFunc {
+		return func(s *rest.Session) {
+			ctx := propagator.Extract(s.Request.Context(), propagation.HeaderCarrier(s.Request.Header))
+			ctx, span := tracer.Start(ctx, s.Request.Method+" "+s.Request.URL.Path)
+			defer span.End()
+			span.SetAttributes(
+				attribute.String("http.method", s.Request.Method),
+				attribute.String("http.target", s.Request.URL.Path),
+			)
+			s.Request = s.Request.WithContext(ctx)
+			next(s)
+			span.SetAttributes(attribute.Int("http.status_code", statusOf(s)))
+		}
+	}
+}
+
+func statusOf(s *rest.Session) int {
+	if sw, ok := s.ResponseWriter.(*statusWriter); ok {
+		return sw.status
+	}
+	return 0
+}
+
+// ClientTracer adapts a trace.Tracer into a rest.Tracer, injecting a W3C
+// traceparent header into outbound requests so the client side of a call
+// joins the same trace as Tracing's server-side spans.
+type ClientTracer struct {
+	Tracer trace.Tracer
+}
+
+func (t *ClientTracer) Propagate(req *http.Request) func(res *http.Response, err error) {
+	ctx, span := t.Tracer.Start(req.Context(), req.Method+" "+req.URL.Path)
+	propagation.TraceContext{}.Inject(ctx, propagation.HeaderCarrier(req.Header))
+	*req = *req.WithContext(ctx)
+	return func(res *http.Response, err error) {
+		defer span.End()
+		if err != nil {
+			span.RecordError(err)
+			return
+		}
+		span.SetAttributes(attribute.Int("http.status_code", res.StatusCode))
+	}
+}
@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/jetrtc/rest"
+)
+
+// fakeSpan wraps a no-op trace.Span and records whether End was called and
+// what was reported to it, without depending on an SDK exporter.
+type fakeSpan struct {
+	trace.Span
+	ended bool
+	err   error
+	attrs []attribute.KeyValue
+}
+
+func (s *fakeSpan) End(opts ...trace.SpanEndOption)                  { s.ended = true }
+func (s *fakeSpan) RecordError(err error, opts ...trace.EventOption) { s.err = err }
+func (s *fakeSpan) SetAttributes(kv ...attribute.KeyValue)           { s.attrs = append(s.attrs, kv...) }
+
+// fakeTracer hands out fakeSpans and remembers the last one it started.
+type fakeTracer struct {
+	trace.Tracer
+	last *fakeSpan
+}
+
+func (t *fakeTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	t.last = &fakeSpan{Span: trace.SpanFromContext(ctx)}
+	return ctx, t.last
+}
+
+func newFakeTracer() *fakeTracer {
+	noop := trace.NewNoopTracerProvider().Tracer("test")
+	return &fakeTracer{Tracer: noop}
+}
+
+func attrInt(attrs []attribute.KeyValue, key string) (int64, bool) {
+	for _, kv := range attrs {
+		if string(kv.Key) == key {
+			return kv.Value.AsInt64(), true
+		}
+	}
+	return 0, false
+}
+
+func TestTracingSetsStatusAfterHandler(t *testing.T) {
+	ft := newFakeTracer()
+	var spanEndedBeforeHandlerReturned bool
+	handler := Tracing(ft)(func(s *rest.Session) {
+		spanEndedBeforeHandlerReturned = ft.last.ended
+		s.ResponseWriter.WriteHeader(http.StatusTeapot)
+	})
+
+	req := httptest.NewRequest("GET", "/widgets", nil)
+	sw := &statusWriter{ResponseWriter: httptest.NewRecorder()}
+	s := &rest.Session{
+		Data:           make(map[interface{}]interface{}),
+		Request:        req,
+		ResponseWriter: sw,
+	}
+	handler(s)
+
+	if spanEndedBeforeHandlerReturned {
+		t.Fatal("span ended before the handler finished running")
+	}
+	if code, ok := attrInt(ft.last.attrs, "http.status_code"); !ok || code != http.StatusTeapot {
+		t.Fatalf("expected http.status_code attribute %d, got %v (present=%v)", http.StatusTeapot, code, ok)
+	}
+}
+
+func TestClientTracerKeepsSpanOpenForRoundTrip(t *testing.T) {
+	ft := newFakeTracer()
+	ct := &ClientTracer{Tracer: ft}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	finish := ct.Propagate(req)
+	if ft.last.ended {
+		t.Fatal("span was ended by Propagate before the round trip completed")
+	}
+
+	finish(&http.Response{StatusCode: http.StatusOK}, nil)
+	if !ft.last.ended {
+		t.Fatal("expected finish to end the span")
+	}
+	if code, ok := attrInt(ft.last.attrs, "http.status_code"); !ok || code != http.StatusOK {
+		t.Fatalf("expected http.status_code attribute %d, got %v (present=%v)", http.StatusOK, code, ok)
+	}
+}
+
+func TestClientTracerRecordsErrorWithoutStatus(t *testing.T) {
+	ft := newFakeTracer()
+	ct := &ClientTracer{Tracer: ft}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	finish := ct.Propagate(req)
+	wantErr := context.DeadlineExceeded
+	finish(nil, wantErr)
+
+	if !ft.last.ended {
+		t.Fatal("expected finish to end the span even on error")
+	}
+	if ft.last.err != wantErr {
+		t.Fatalf("expected RecordError(%v), got %v", wantErr, ft.last.err)
+	}
+}
@@ -0,0 +1,137 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// ProblemContentType is the media type for structured error responses, per
+// RFC 7807 (https://tools.ietf.org/html/rfc7807).
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 "problem detail", used in place of the plain-text
+// bodies http.Error writes. Extensions holds any additional members beyond
+// the five standard fields.
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]interface{}
+}
+
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return fmt.Sprintf("%s: %s", p.Title, p.Detail)
+	}
+	return p.Title
+}
+
+// MarshalJSON flattens Extensions alongside the RFC 7807 fields.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	m := make(map[string]interface{}, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		m[k] = v
+	}
+	if p.Type != "" {
+		m["type"] = p.Type
+	}
+	if p.Title != "" {
+		m["title"] = p.Title
+	}
+	if p.Status != 0 {
+		m["status"] = p.Status
+	}
+	if p.Detail != "" {
+		m["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		m["instance"] = p.Instance
+	}
+	return json.Marshal(m)
+}
+
+var problemFields = map[string]bool{"type": true, "title": true, "status": true, "detail": true, "instance": true}
+
+// UnmarshalJSON collects any member outside the five standard fields into Extensions.
+func (p *Problem) UnmarshalJSON(data []byte) error {
+	type alias Problem
+	if err := json.Unmarshal(data, (*alias)(p)); err != nil {
+		return err
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	for k := range problemFields {
+		delete(m, k)
+	}
+	if len(m) == 0 {
+		return nil
+	}
+	p.Extensions = make(map[string]interface{}, len(m))
+	for k, raw := range m {
+		var v interface{}
+		if err := json.Unmarshal(raw, &v); err == nil {
+			p.Extensions[k] = v
+		}
+	}
+	return nil
+}
+
+// BadRequestProblem builds a 400 Problem for a client-supplied-data error,
+// e.g. a request body that failed to decode.
+func BadRequestProblem(detail string) *Problem {
+	return &Problem{
+		Title:  http.StatusText(http.StatusBadRequest),
+		Status: http.StatusBadRequest,
+		Detail: detail,
+	}
+}
+
+// ErrorMapper maps err to a Problem, returning ok=false if it doesn't apply.
+// Session.Fail tries registered mappers, most recently registered first,
+// before falling back to a generic 500.
+type ErrorMapper func(err error) (problem *Problem, ok bool)
+
+// Fail writes err as a structured problem+json response. If err is already
+// a *Problem it is written as-is; otherwise registered ErrorMappers are
+// tried in order, and failing that err is reported as a 500.
+func (s *Session) Fail(err error) {
+	if p, ok := err.(*Problem); ok {
+		s.WriteProblem(p)
+		return
+	}
+	for _, mapper := range s.server.errorMappers {
+		if p, ok := mapper(err); ok {
+			s.WriteProblem(p)
+			return
+		}
+	}
+	s.WriteProblem(&Problem{
+		Title:  http.StatusText(http.StatusInternalServerError),
+		Status: http.StatusInternalServerError,
+		Detail: err.Error(),
+	})
+}
+
+// WriteProblem writes p as an application/problem+json response.
+func (s *Session) WriteProblem(p *Problem) {
+	if p.Status == 0 {
+		p.Status = http.StatusInternalServerError
+	}
+	s.Debugf("Writing problem: %d \"%s\"", p.Status, p.Title)
+	data, err := json.Marshal(p)
+	if err != nil {
+		s.Errorf("Failed to encode problem: %s", err.Error())
+		http.Error(s.ResponseWriter, p.Error(), p.Status)
+		return
+	}
+	s.ResponseHeader().Set(ContentType, ProblemContentType)
+	s.ResponseWriter.WriteHeader(p.Status)
+	if _, err := s.ResponseWriter.Write(data); err != nil {
+		s.Errorf("Failed to write problem: %s", err.Error())
+	}
+}
@@ -0,0 +1,36 @@
+package rest
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestProblemJSON(t *testing.T) {
+	p := &Problem{
+		Title:      "Bad Request",
+		Status:     400,
+		Detail:     "email is required",
+		Extensions: map[string]interface{}{"field": "email"},
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("Marshal returned error: %s", err.Error())
+	}
+	var got Problem
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal returned error: %s", err.Error())
+	}
+	if got.Title != p.Title || got.Status != p.Status || got.Detail != p.Detail {
+		t.Fatalf("round-trip mismatch: got %+v, want %+v", got, p)
+	}
+	if got.Extensions["field"] != "email" {
+		t.Fatalf("expected extension field to round-trip, got %v", got.Extensions)
+	}
+}
+
+func TestProblemError(t *testing.T) {
+	p := &Problem{Title: "Bad Request", Detail: "email is required"}
+	if p.Error() != "Bad Request: email is required" {
+		t.Fatalf("unexpected Error() string: %s", p.Error())
+	}
+}
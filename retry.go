@@ -0,0 +1,88 @@
+package rest
+
+import (
+	"math/rand"
+	"net"
+	"time"
+)
+
+// RetryPolicy configures Client.Retry's handling of transient failures:
+// which responses and errors are retryable, how many times to try, and how
+// long to wait between attempts.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	// Values <= 1 disable retries.
+	MaxAttempts int
+	// RetryableStatus lists response status codes that should be retried.
+	// Defaults to 502, 503, and 504 when nil.
+	RetryableStatus []int
+	// RetryableError reports whether err, from the round trip itself,
+	// should be retried. Defaults to net.Error timeouts when nil.
+	RetryableError func(err error) bool
+	// Timeout, if non-zero, bounds each individual attempt.
+	Timeout time.Duration
+	// Backoff computes the delay before the next attempt. Defaults to
+	// ExponentialBackoff{Base: 100ms, Cap: 5s} when nil.
+	Backoff BackoffStrategy
+}
+
+func (p *RetryPolicy) retryableStatus(code int) bool {
+	statuses := p.RetryableStatus
+	if statuses == nil {
+		statuses = []int{502, 503, 504}
+	}
+	for _, s := range statuses {
+		if s == code {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *RetryPolicy) retryableError(err error) bool {
+	if p.RetryableError != nil {
+		return p.RetryableError(err)
+	}
+	if ne, ok := err.(net.Error); ok {
+		return ne.Timeout()
+	}
+	return false
+}
+
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	b := p.Backoff
+	if b == nil {
+		b = ExponentialBackoff{Base: 100 * time.Millisecond, Cap: 5 * time.Second}
+	}
+	return b.Backoff(attempt)
+}
+
+// BackoffStrategy computes the delay before retry attempt n (1-based: the
+// delay before the 2nd overall attempt is Backoff(1)).
+type BackoffStrategy interface {
+	Backoff(attempt int) time.Duration
+}
+
+// ConstantBackoff waits the same duration before every attempt.
+type ConstantBackoff time.Duration
+
+func (b ConstantBackoff) Backoff(attempt int) time.Duration {
+	return time.Duration(b)
+}
+
+// ExponentialBackoff implements the full-jitter algorithm:
+// sleep = rand(0, min(Cap, Base*2^attempt)).
+type ExponentialBackoff struct {
+	Base, Cap time.Duration
+}
+
+func (b ExponentialBackoff) Backoff(attempt int) time.Duration {
+	max := b.Cap
+	if shifted := b.Base << uint(attempt); shifted > 0 && shifted < b.Cap {
+		max = shifted
+	}
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(max) + 1))
+}
@@ -0,0 +1,33 @@
+package rest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyRetryableStatus(t *testing.T) {
+	p := &RetryPolicy{}
+	if !p.retryableStatus(503) {
+		t.Fatal("expected default policy to retry 503")
+	}
+	if p.retryableStatus(404) {
+		t.Fatal("expected default policy not to retry 404")
+	}
+}
+
+func TestExponentialBackoffRespectsCap(t *testing.T) {
+	b := ExponentialBackoff{Base: 100 * time.Millisecond, Cap: 400 * time.Millisecond}
+	for attempt := 0; attempt < 10; attempt++ {
+		d := b.Backoff(attempt)
+		if d > b.Cap {
+			t.Fatalf("attempt %d: backoff %v exceeded cap %v", attempt, d, b.Cap)
+		}
+	}
+}
+
+func TestConstantBackoff(t *testing.T) {
+	b := ConstantBackoff(50 * time.Millisecond)
+	if b.Backoff(1) != 50*time.Millisecond || b.Backoff(5) != 50*time.Millisecond {
+		t.Fatal("expected constant backoff regardless of attempt")
+	}
+}
@@ -27,7 +27,7 @@ func UserHandler(s *rest.Session) {
 		user := &User{}
 		err := s.Decode(user)
 		if err != nil {
-			s.Status(400, nil)
+			s.Error(err)
 			return
 		}
 		users[id] = user
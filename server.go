@@ -43,20 +43,46 @@ func (rt *route) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 type Server struct {
 	log.Sugar
-	jsonPrefix, jsonIndent string
-	middlewares            []MiddlewareFunc
+	jsonCodec    *JSONCodec
+	codecs       *codecRegistry
+	middlewares  []MiddlewareFunc
+	errorMappers []ErrorMapper
 }
 
 func NewServer(logger log.Logger) *Server {
+	jsonCodec := &JSONCodec{}
 	return &Server{
-		Sugar:       log.NewSugar(logger),
+		Sugar:     log.NewSugar(logger),
+		jsonCodec: jsonCodec,
+		// jsonCodec is registered ahead of ProtoCodec so negotiate's
+		// fallback (no Accept header, "Accept: */*", or an ordinary
+		// browser Accept list, none of which match either codec's
+		// Accepts) serves JSON by default, matching the pre-Codec
+		// behavior where only an explicit protobuf Content-Type or
+		// Accept selected binary output.
+		codecs:      newCodecRegistry(jsonCodec, &ProtoCodec{}),
 		middlewares: make([]MiddlewareFunc, 0),
 	}
 }
 
+// RegisterErrorMapper adds m ahead of any existing error mappers, so it is
+// tried first by Session.Fail.
+func (s *Server) RegisterErrorMapper(m ErrorMapper) *Server {
+	s.errorMappers = append([]ErrorMapper{m}, s.errorMappers...)
+	return s
+}
+
 func (s *Server) JSONIndent(prefix, indent string) {
-	s.jsonPrefix = prefix
-	s.jsonIndent = indent
+	s.jsonCodec.Prefix = prefix
+	s.jsonCodec.Indent = indent
+}
+
+// RegisterCodec adds c to the server's codec registry, ahead of the
+// built-in JSON and protobuf codecs, so it is tried first for matching
+// content types.
+func (s *Server) RegisterCodec(c Codec) *Server {
+	s.codecs.register(c)
+	return s
 }
 
 func (s *Server) Post(r *mux.Route, handler HandlerFunc) *mux.Route {
@@ -1,16 +1,12 @@
 package rest
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
 	"strings"
 
-	"github.com/golang/protobuf/proto"
 	"github.com/gorilla/mux"
 	"github.com/jetrtc/log"
 )
@@ -59,45 +55,76 @@ func (s *Session) ResponseHeader() http.Header {
 	return s.ResponseWriter.Header()
 }
 
+// Decode reads the request body into val per its Content-Type, binds path
+// variables and query parameters into matching fields by name, and, if val
+// implements Validator, invokes Validate(). A decode failure or a failed
+// Validate() is returned as a *Problem suitable for Session.Fail.
 func (s *Session) Decode(val interface{}) error {
-	switch v := val.(type) {
-	case proto.Message:
-		if isProto(contentType(s.Request)) {
-			data, err := ioutil.ReadAll(s.Request.Body)
-			if err != nil {
-				s.Errorf("Failed to read request body: %s", err.Error())
-				return err
-			}
-			err = proto.Unmarshal(data, v)
-			if err != nil {
-				s.Errorf("Failed to unmarshal proto request body: %s", err.Error())
-			}
-			return err
-		} else {
-			err := json.NewDecoder(s.Request.Body).Decode(v)
-			if err != nil {
-				s.Errorf("Failed to decode JSON request body: %s", err.Error())
-				return err
-			}
-			return nil
+	if err := s.DecodeBody(val); err != nil {
+		return err
+	}
+	if err := BindFields(val, s.Vars()); err != nil {
+		s.Errorf("Failed to bind path vars: %s", err.Error())
+		return BadRequestProblem(err.Error())
+	}
+	if err := BindQuery(val, s.Request.URL.Query()); err != nil {
+		s.Errorf("Failed to bind query params: %s", err.Error())
+		return BadRequestProblem(err.Error())
+	}
+	if v, ok := val.(Validator); ok {
+		if err := v.Validate(); err != nil {
+			s.Debugf("Validation failed: %s", err.Error())
+			return validationProblem(err)
 		}
-	default:
-		return json.NewDecoder(s.Request.Body).Decode(v)
 	}
+	return nil
 }
 
-func (s *Session) Encode(val interface{}) error {
-	switch v := val.(type) {
-	case proto.Message:
-		accept := accepts(ProtobufContentTypes, s.RequestHeader()["Accept"])
-		if isProto(contentType(s.Request)) || accept != "" {
-			return s.encodeProto(v, accept)
-		} else {
-			return s.encodeJSON(v)
+// DecodeBody reads the request body into val per its Content-Type, without
+// binding path variables or query parameters or running Validate. It's the
+// building block Decode is made of; use it directly when a caller binds
+// path vars and query params itself, such as transcode, which must skip
+// query binding for HTTPRules whose Body claims the whole message.
+func (s *Session) DecodeBody(val interface{}) error {
+	codec := s.server.codecs.byContentType(contentType(s.Request))
+	if codec == nil {
+		codec = &JSONCodec{}
+	}
+	data, err := ioutil.ReadAll(s.Request.Body)
+	if err != nil {
+		s.Errorf("Failed to read request body: %s", err.Error())
+		return err
+	}
+	if len(data) > 0 {
+		if err := codec.Unmarshal(data, val); err != nil {
+			s.Errorf("Failed to decode request body: %s", err.Error())
+			return BadRequestProblem(err.Error())
 		}
-	default:
-		return s.encodeJSON(v)
 	}
+	return nil
+}
+
+func (s *Session) Encode(val interface{}) error {
+	accepts := s.RequestHeader()["Accept"]
+	if ct := contentType(s.Request); isTypeOf(ct, ProtobufContentTypes) {
+		accepts = append([]string{ct}, accepts...)
+	}
+	codec := s.server.codecs.negotiate(val, accepts)
+	if codec == nil {
+		codec = s.server.jsonCodec
+	}
+	data, err := codec.Marshal(val)
+	if err != nil {
+		s.Errorf("Failed to encode response: %s", err.Error())
+		return err
+	}
+	s.ResponseHeader().Set(ContentType, codec.ContentType())
+	s.Debugf("Writing %s: %d bytes", codec.ContentType(), len(data))
+	if _, err := s.ResponseWriter.Write(data); err != nil {
+		s.Errorf("Failed to write response: %s", err.Error())
+		return err
+	}
+	return nil
 }
 
 func (s *Session) Status(code int) {
@@ -111,7 +138,7 @@ func (s *Session) Statusf(code int, format string, args ...interface{}) {
 }
 
 func (s *Session) Error(err error) {
-	s.Statusf(500, err.Error())
+	s.Fail(err)
 }
 
 func (s *Session) Vars() map[string]string {
@@ -129,49 +156,10 @@ func (s *Session) Var(key, preset string) string {
 	return val
 }
 
-func (s *Session) encodeProto(v proto.Message, accept string) error {
-	if accept == "" {
-		accept = ProtobufContentTypes[0]
-	}
-	s.ResponseHeader().Set(ContentType, accept)
-	data, err := proto.Marshal(v)
-	if err != nil {
-		s.Errorf("Failed to encode protobuf: %s", err.Error())
-		return err
-	}
-	s.Debugf("Writing protobuf: %d bytes", len(data))
-	_, err = io.Copy(s.ResponseWriter, bytes.NewBuffer(data))
-	if err != nil {
-		s.Errorf("Failed to write protobuf: %s", err.Error())
-		return err
-	}
-	return nil
-}
-
-func (s *Session) encodeJSON(v interface{}) error {
-	s.ResponseHeader().Set(ContentType, JsonContentType)
-	data, err := json.MarshalIndent(v, s.server.jsonPrefix, s.server.jsonIndent)
-	if err != nil {
-		s.Errorf("Failed to encode JSON: %s", err.Error())
-		return err
-	}
-	s.Debugf("Writing JSON: %d bytes", len(data))
-	_, err = s.ResponseWriter.Write(data)
-	if err != nil {
-		s.Errorf("Failed to write JSON: %s", err.Error())
-		return err
-	}
-	return nil
-}
-
 func contentType(r *http.Request) string {
 	return r.Header.Get(ContentType)
 }
 
-func isProto(mime string) bool {
-	return isTypeOf(mime, ProtobufContentTypes)
-}
-
 func isTypeOf(mime string, types []string) bool {
 	for _, t := range types {
 		if strings.HasPrefix(mime, t) {
@@ -180,14 +168,3 @@ func isTypeOf(mime string, types []string) bool {
 	}
 	return false
 }
-
-func accepts(types []string, accepts []string) string {
-	for _, t := range types {
-		for _, a := range accepts {
-			if strings.HasPrefix(a, t) {
-				return t
-			}
-		}
-	}
-	return ""
-}
@@ -0,0 +1,48 @@
+package rest
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+)
+
+func TestSessionEncodeProtoDefaultsToJSON(t *testing.T) {
+	cases := []struct {
+		name   string
+		accept string
+	}{
+		{"no Accept header", ""},
+		{"wildcard Accept", "*/*"},
+		{"ordinary browser Accept list", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if c.accept != "" {
+				req.Header.Set("Accept", c.accept)
+			}
+			rec := httptest.NewRecorder()
+			s := newStreamTestSession(rec, req)
+			if err := s.Encode(&streamTestMessage{Name: proto.String("alice")}); err != nil {
+				t.Fatalf("Encode returned error: %s", err.Error())
+			}
+			if ct := rec.Header().Get(ContentType); ct != JsonContentType {
+				t.Fatalf("expected Content-Type %q, got %q", JsonContentType, ct)
+			}
+		})
+	}
+}
+
+func TestSessionEncodeProtoWithProtobufAccept(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", ProtobufContentTypes[0])
+	rec := httptest.NewRecorder()
+	s := newStreamTestSession(rec, req)
+	if err := s.Encode(&streamTestMessage{Name: proto.String("alice")}); err != nil {
+		t.Fatalf("Encode returned error: %s", err.Error())
+	}
+	if ct := rec.Header().Get(ContentType); ct != ProtobufContentTypes[0] {
+		t.Fatalf("expected Content-Type %q, got %q", ProtobufContentTypes[0], ct)
+	}
+}
@@ -0,0 +1,153 @@
+package rest
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/golang/protobuf/proto"
+)
+
+const (
+	NDJSONContentType      = "application/x-ndjson"
+	EventStreamContentType = "text/event-stream"
+)
+
+// flushWriter flushes the underlying ResponseWriter after every Write, so a
+// handler can push many messages over a single HTTP response the way
+// grpc-gateway does for server-streaming RPCs.
+type flushWriter struct {
+	w http.ResponseWriter
+	f http.Flusher
+}
+
+func (fw *flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	if err != nil {
+		return n, err
+	}
+	fw.f.Flush()
+	return n, nil
+}
+
+// Stream sets contentType on the response and returns a writer that flushes
+// after every Write. Use EventStream, NDJSON or StreamProto for the common
+// framings; Stream is for handlers that want to write the body themselves.
+func (s *Session) Stream(contentType string) (io.Writer, error) {
+	f, ok := s.ResponseWriter.(http.Flusher)
+	if !ok {
+		return nil, errors.New("rest: ResponseWriter does not support flushing")
+	}
+	s.ResponseHeader().Set(ContentType, contentType)
+	return &flushWriter{w: s.ResponseWriter, f: f}, nil
+}
+
+// EventStream is a Server-Sent Events encoder returned by Session.EventStream.
+type EventStream struct {
+	s *Session
+	w io.Writer
+}
+
+// EventStream starts a text/event-stream response.
+func (s *Session) EventStream() (*EventStream, error) {
+	w, err := s.Stream(EventStreamContentType)
+	if err != nil {
+		return nil, err
+	}
+	return &EventStream{s: s, w: w}, nil
+}
+
+// Send writes one SSE event, JSON-encoding v as the "data:" field. event may
+// be empty to omit the "event:" field.
+func (es *EventStream) Send(event string, v interface{}) error {
+	if err := es.s.Request.Context().Err(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		es.s.Errorf("Failed to marshal SSE data: %s", err.Error())
+		return err
+	}
+	if event != "" {
+		if _, err := fmt.Fprintf(es.w, "event: %s\n", event); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(es.w, "data: %s\n\n", data); err != nil {
+		es.s.Errorf("Failed to write SSE event: %s", err.Error())
+		return err
+	}
+	return nil
+}
+
+// NDJSONEncoder streams newline-delimited JSON, one value per line.
+type NDJSONEncoder struct {
+	s *Session
+	w io.Writer
+}
+
+// NDJSON starts an application/x-ndjson response.
+func (s *Session) NDJSON() (*NDJSONEncoder, error) {
+	w, err := s.Stream(NDJSONContentType)
+	if err != nil {
+		return nil, err
+	}
+	return &NDJSONEncoder{s: s, w: w}, nil
+}
+
+func (e *NDJSONEncoder) Encode(v interface{}) error {
+	if err := e.s.Request.Context().Err(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		e.s.Errorf("Failed to marshal NDJSON: %s", err.Error())
+		return err
+	}
+	if _, err := e.w.Write(append(data, '\n')); err != nil {
+		e.s.Errorf("Failed to write NDJSON: %s", err.Error())
+		return err
+	}
+	return nil
+}
+
+// ProtoStreamEncoder streams length-prefixed protobuf frames: a big-endian
+// uint32 byte count followed by the marshaled message.
+type ProtoStreamEncoder struct {
+	s *Session
+	w io.Writer
+}
+
+// StreamProto starts an application/protobuf response of length-prefixed frames.
+func (s *Session) StreamProto() (*ProtoStreamEncoder, error) {
+	w, err := s.Stream(ProtobufContentTypes[0])
+	if err != nil {
+		return nil, err
+	}
+	return &ProtoStreamEncoder{s: s, w: w}, nil
+}
+
+func (e *ProtoStreamEncoder) Encode(v proto.Message) error {
+	if err := e.s.Request.Context().Err(); err != nil {
+		return err
+	}
+	data, err := proto.Marshal(v)
+	if err != nil {
+		e.s.Errorf("Failed to marshal protobuf frame: %s", err.Error())
+		return err
+	}
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(data)))
+	if _, err := e.w.Write(size[:]); err != nil {
+		e.s.Errorf("Failed to write protobuf frame size: %s", err.Error())
+		return err
+	}
+	if _, err := e.w.Write(data); err != nil {
+		e.s.Errorf("Failed to write protobuf frame: %s", err.Error())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package rest
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/jetrtc/log"
+)
+
+func newStreamTestSession(w http.ResponseWriter, r *http.Request) *Session {
+	logger := log.NewLogger(func(lv log.Level, payload interface{}) {})
+	return &Session{
+		Context:        log.NewContext(r.Context(), log.NewSugar(logger)),
+		server:         NewServer(logger),
+		Data:           make(map[interface{}]interface{}),
+		Request:        r,
+		ResponseWriter: w,
+	}
+}
+
+// nonFlushingWriter wraps http.ResponseWriter without exposing Flush, so
+// Stream can be tested against a ResponseWriter that genuinely doesn't
+// support it.
+type nonFlushingWriter struct {
+	http.ResponseWriter
+}
+
+func TestSessionStreamRequiresFlusher(t *testing.T) {
+	rec := httptest.NewRecorder()
+	s := newStreamTestSession(&nonFlushingWriter{rec}, httptest.NewRequest("GET", "/", nil))
+	if _, err := s.Stream(NDJSONContentType); err == nil {
+		t.Fatal("expected Stream to fail for a ResponseWriter without Flush")
+	}
+}
+
+func TestSessionNDJSONStream(t *testing.T) {
+	rec := httptest.NewRecorder()
+	s := newStreamTestSession(rec, httptest.NewRequest("GET", "/", nil))
+	enc, err := s.NDJSON()
+	if err != nil {
+		t.Fatalf("NDJSON returned error: %s", err.Error())
+	}
+	if err := enc.Encode(map[string]int{"n": 1}); err != nil {
+		t.Fatalf("Encode returned error: %s", err.Error())
+	}
+	if err := enc.Encode(map[string]int{"n": 2}); err != nil {
+		t.Fatalf("Encode returned error: %s", err.Error())
+	}
+	if ct := rec.Header().Get(ContentType); ct != NDJSONContentType {
+		t.Fatalf("expected Content-Type %q, got %q", NDJSONContentType, ct)
+	}
+	scanner := bufio.NewScanner(rec.Body)
+	var lines []map[string]int
+	for scanner.Scan() {
+		var v map[string]int
+		if err := json.Unmarshal(scanner.Bytes(), &v); err != nil {
+			t.Fatalf("failed to unmarshal line %q: %s", scanner.Text(), err.Error())
+		}
+		lines = append(lines, v)
+	}
+	if len(lines) != 2 || lines[0]["n"] != 1 || lines[1]["n"] != 2 {
+		t.Fatalf("unexpected NDJSON body: %q", rec.Body.String())
+	}
+}
+
+func TestSessionEventStreamSend(t *testing.T) {
+	rec := httptest.NewRecorder()
+	s := newStreamTestSession(rec, httptest.NewRequest("GET", "/", nil))
+	es, err := s.EventStream()
+	if err != nil {
+		t.Fatalf("EventStream returned error: %s", err.Error())
+	}
+	if err := es.Send("ping", map[string]string{"msg": "hi"}); err != nil {
+		t.Fatalf("Send returned error: %s", err.Error())
+	}
+	if ct := rec.Header().Get(ContentType); ct != EventStreamContentType {
+		t.Fatalf("expected Content-Type %q, got %q", EventStreamContentType, ct)
+	}
+	want := "event: ping\ndata: {\"msg\":\"hi\"}\n\n"
+	if rec.Body.String() != want {
+		t.Fatalf("unexpected SSE body: got %q, want %q", rec.Body.String(), want)
+	}
+}
+
+type streamTestMessage struct {
+	Name *string `protobuf:"bytes,1,opt,name=name,json=name"`
+}
+
+func (m *streamTestMessage) Reset()         { *m = streamTestMessage{} }
+func (m *streamTestMessage) String() string { return "" }
+func (m *streamTestMessage) ProtoMessage()  {}
+
+func TestSessionStreamProtoFrames(t *testing.T) {
+	rec := httptest.NewRecorder()
+	s := newStreamTestSession(rec, httptest.NewRequest("GET", "/", nil))
+	enc, err := s.StreamProto()
+	if err != nil {
+		t.Fatalf("StreamProto returned error: %s", err.Error())
+	}
+	if err := enc.Encode(&streamTestMessage{Name: proto.String("alice")}); err != nil {
+		t.Fatalf("Encode returned error: %s", err.Error())
+	}
+	body := rec.Body.Bytes()
+	if len(body) < 4 {
+		t.Fatalf("expected a length-prefixed frame, got %d bytes", len(body))
+	}
+	size := binary.BigEndian.Uint32(body[:4])
+	msg := &streamTestMessage{}
+	if err := proto.Unmarshal(body[4:4+size], msg); err != nil {
+		t.Fatalf("Unmarshal returned error: %s", err.Error())
+	}
+	if msg.Name == nil || *msg.Name != "alice" {
+		t.Fatalf("unexpected decoded message: %v", msg)
+	}
+}
@@ -0,0 +1,23 @@
+package rest
+
+import "net/http"
+
+// Tracer lets a Client participate in a distributed trace by adding
+// propagation headers (e.g. a W3C traceparent) to each outbound request
+// before it is sent. dumpRequest and dumpResponse already log every
+// header, so once Propagate has run, the client's debug log correlates
+// with whatever span the receiving server's tracing middleware started.
+//
+// Propagate returns a finish func, which the Client calls with the round
+// trip's outcome once it completes, so an implementation that opens a span
+// can close it with the real request duration and status instead of one
+// that covers only header injection.
+type Tracer interface {
+	Propagate(req *http.Request) (finish func(res *http.Response, err error))
+}
+
+// Trace sets the Tracer used to annotate outbound requests.
+func (c *Client) Trace(t Tracer) *Client {
+	c.tracer = t
+	return c
+}
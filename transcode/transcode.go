@@ -0,0 +1,156 @@
+// Package transcode provides a grpc-gateway style transcoding layer on top
+// of rest.Server: it maps google.api.http style HTTP rules onto protobuf
+// request/response messages and registers them as ordinary mux routes.
+package transcode
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/gorilla/mux"
+	"github.com/jetrtc/rest"
+)
+
+// HTTPRule describes a single google.api.http binding for an RPC method:
+// an HTTP method, a URL template (using "{var}" and "{var=**}" wildcards),
+// and the field (or "*") that the request body maps onto.
+type HTTPRule struct {
+	Method  string
+	Pattern string
+	Body    string
+}
+
+// Binding ties one HTTPRule to the request/response messages and handler
+// of an RPC method, in place of the boilerplate a hand-written HandlerFunc
+// would otherwise need.
+type Binding struct {
+	Rule    HTTPRule
+	NewReq  func() proto.Message
+	Handler func(s *rest.Session, req proto.Message) (proto.Message, error)
+}
+
+// Register walks bindings and registers one mux route per HTTPRule on r,
+// decoding requests and encoding responses through the Session's existing
+// content negotiation.
+func Register(srv *rest.Server, r *mux.Router, bindings []Binding) error {
+	for _, b := range bindings {
+		b := b
+		pattern, err := muxPattern(b.Rule.Pattern)
+		if err != nil {
+			return fmt.Errorf("transcode: %s %s: %s", b.Rule.Method, b.Rule.Pattern, err.Error())
+		}
+		r.Path(pattern).Methods(b.Rule.Method).Handler(srv.HandlerFunc(func(s *rest.Session) {
+			serve(s, b)
+		}))
+	}
+	return nil
+}
+
+func serve(s *rest.Session, b Binding) {
+	req := b.NewReq()
+	if b.Rule.Body != "" {
+		target := proto.Message(req)
+		if b.Rule.Body != "*" {
+			sub, err := bodyField(req, b.Rule.Body)
+			if err != nil {
+				s.Errorf("Failed to resolve body field %q: %s", b.Rule.Body, err.Error())
+				s.Fail(rest.BadRequestProblem(fmt.Sprintf("invalid body field: %s", err.Error())))
+				return
+			}
+			target = sub
+		}
+		// DecodeBody, not Decode: Decode also binds query params
+		// unconditionally, which would let a query param matching a
+		// proto field name overwrite a body-decoded value even when
+		// Body == "*" claims the whole message for the body.
+		if err := s.DecodeBody(target); err != nil {
+			s.Fail(err)
+			return
+		}
+	}
+	if err := rest.BindFields(req, s.Vars()); err != nil {
+		s.Errorf("Failed to bind path vars: %s", err.Error())
+		s.Fail(rest.BadRequestProblem(fmt.Sprintf("invalid path parameter: %s", err.Error())))
+		return
+	}
+	if b.Rule.Body != "*" {
+		query := make(map[string]string)
+		for k, v := range s.Request.URL.Query() {
+			if len(v) > 0 {
+				query[k] = v[0]
+			}
+		}
+		if err := rest.BindFields(req, query); err != nil {
+			s.Errorf("Failed to bind query params: %s", err.Error())
+			s.Fail(rest.BadRequestProblem(fmt.Sprintf("invalid query parameter: %s", err.Error())))
+			return
+		}
+	}
+	res, err := b.Handler(s, req)
+	if err != nil {
+		s.Error(err)
+		return
+	}
+	s.Encode(res)
+}
+
+// bodyField returns the sub-message field of req named by a google.api.http
+// field-selector Body (e.g. "user"), matched by protobuf wire name (the
+// generated struct's `protobuf:"...,name=x"` tag) or, failing that,
+// case-insensitive Go field name, same as rest.BindFields. A nil pointer
+// field is allocated so the caller can decode straight into it.
+func bodyField(req proto.Message, name string) (proto.Message, error) {
+	v := reflect.ValueOf(req)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("transcode: %T is not a pointer to struct", req)
+	}
+	elem := v.Elem()
+	t := elem.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if bodyFieldWireName(f) != name && !strings.EqualFold(f.Name, name) {
+			continue
+		}
+		fv := elem.Field(i)
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				fv.Set(reflect.New(fv.Type().Elem()))
+			}
+			if m, ok := fv.Interface().(proto.Message); ok {
+				return m, nil
+			}
+		}
+		return nil, fmt.Errorf("transcode: field %q is not a proto.Message", name)
+	}
+	return nil, fmt.Errorf("transcode: no field named %q", name)
+}
+
+func bodyFieldWireName(f reflect.StructField) string {
+	tag := f.Tag.Get("protobuf")
+	for _, part := range strings.Split(tag, ",") {
+		if strings.HasPrefix(part, "name=") {
+			return strings.TrimPrefix(part, "name=")
+		}
+	}
+	return ""
+}
+
+var pathVarRe = regexp.MustCompile(`\{([a-zA-Z0-9_.]+)(=[^}]*)?\}`)
+
+// muxPattern translates a google.api.http URL template into a gorilla/mux
+// route pattern: "{var}" maps straight across, and "{var=**}" (or any
+// multi-segment wildcard) becomes a mux regexp var matching the rest of
+// the path.
+func muxPattern(template string) (string, error) {
+	return pathVarRe.ReplaceAllStringFunc(template, func(m string) string {
+		parts := pathVarRe.FindStringSubmatch(m)
+		name, wildcard := parts[1], parts[2]
+		if strings.Contains(wildcard, "**") {
+			return "{" + name + ":.*}"
+		}
+		return "{" + name + "}"
+	}), nil
+}
@@ -0,0 +1,53 @@
+package rest
+
+// Validator is implemented by request types that can check their own
+// invariants, e.g. protoc-gen-validate's generated Validate() method.
+// Session.Decode calls it, if implemented, after decoding and binding.
+type Validator interface {
+	Validate() error
+}
+
+// FieldError is implemented by protoc-gen-validate's generated per-field
+// validation errors, letting Session.Decode report which field failed
+// instead of a single opaque message.
+type FieldError interface {
+	error
+	Field() string
+	Reason() string
+}
+
+// multiFieldError is implemented by protoc-gen-validate's generated
+// *MultiError, which bundles every failing field's error together.
+type multiFieldError interface {
+	AllErrors() []error
+}
+
+type fieldViolation struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// validationProblem turns a Validate() error into a 400 Problem, attaching
+// a "fields" extension listing each offending field when err (or its
+// *MultiError members) implement FieldError.
+func validationProblem(err error) *Problem {
+	p := BadRequestProblem(err.Error())
+	if fields := fieldErrors(err); len(fields) > 0 {
+		p.Extensions = map[string]interface{}{"fields": fields}
+	}
+	return p
+}
+
+func fieldErrors(err error) []fieldViolation {
+	errs := []error{err}
+	if m, ok := err.(multiFieldError); ok {
+		errs = m.AllErrors()
+	}
+	var out []fieldViolation
+	for _, e := range errs {
+		if fe, ok := e.(FieldError); ok {
+			out = append(out, fieldViolation{Field: fe.Field(), Reason: fe.Reason()})
+		}
+	}
+	return out
+}